@@ -0,0 +1,140 @@
+package ldapdn
+
+import (
+	"fmt"
+	"testing"
+)
+
+// dnCorpus returns n distinct, syntactically valid DN strings to drive the
+// benchmarks below.
+func dnCorpus(n int) []string {
+	corpus := make([]string, n)
+	for i := range corpus {
+		corpus[i] = fmt.Sprintf("cn=User %d+ou=Sales,dc=example%d,dc=org", i, i%50)
+	}
+	return corpus
+}
+
+// TestStringDoesNotMutateAttributes guards against the bug String() used
+// to have: sort.Sort(ava(r.Attributes)) sorted the caller's slice in
+// place, so calling String() twice on the same *DN in different code paths
+// could silently reorder its RelativeDN.Attributes out from under it.
+func TestStringDoesNotMutateAttributes(t *testing.T) {
+	dn, err := New("ou=Sales+cn=J. Smith,dc=example,dc=org")
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+	before := make([]*AttributeTypeAndValue, len(dn.RDNs[0].Attributes))
+	copy(before, dn.RDNs[0].Attributes)
+
+	_ = dn.String()
+
+	after := dn.RDNs[0].Attributes
+	for i := range before {
+		if before[i] != after[i] {
+			t.Fatalf("String() reordered Attributes: before=%v after=%v", before, after)
+		}
+	}
+}
+
+func TestCanonicalBytesMatchesString(t *testing.T) {
+	for _, s := range dnCorpus(64) {
+		dn, err := New(s)
+		if err != nil {
+			t.Fatalf("New(%q): %s", s, err)
+		}
+		want := dn.String()
+		got, err := CanonicalBytes(s)
+		if err != nil {
+			t.Fatalf("CanonicalBytes(%q): %s", s, err)
+		}
+		if string(got) != want {
+			t.Errorf("CanonicalBytes(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestDNAppendToReusesBuffer(t *testing.T) {
+	dn, err := New("cn=J. Smith+ou=Sales,dc=example,dc=org")
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+	buf := make([]byte, 0, 256)
+	buf = append(buf, "prefix:"...)
+	buf = dn.appendTo(buf)
+	if string(buf) != "prefix:"+dn.String() {
+		t.Errorf("appendTo(buf) = %q, want %q", buf, "prefix:"+dn.String())
+	}
+}
+
+// TestAppendToAllocBudget checks that appending a DN's canonical form into
+// a reused, pre-sized buffer doesn't allocate per RDN attribute sort, which
+// was the point of moving the sort onto a stack-allocated index array.
+func TestAppendToAllocBudget(t *testing.T) {
+	dn, err := New("cn=J. Smith+ou=Sales,dc=example,dc=org")
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+	buf := make([]byte, 0, 256)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = buf[:0]
+		buf = dn.appendTo(buf)
+	})
+	if allocs > 1 {
+		t.Errorf("appendTo into a reused buffer allocated %.1f times per run, want <= 1", allocs)
+	}
+}
+
+func BenchmarkParseDN(b *testing.B) {
+	corpus := dnCorpus(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := New(corpus[i%len(corpus)]); err != nil {
+			b.Fatalf("New: %s", err)
+		}
+	}
+}
+
+// BenchmarkCanonicalDN exercises the New()+String() path: parse into a
+// *DN, then stringify it.
+func BenchmarkCanonicalDN(b *testing.B) {
+	corpus := dnCorpus(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := CanonicalDN(corpus[i%len(corpus)]); err != nil {
+			b.Fatalf("CanonicalDN: %s", err)
+		}
+	}
+}
+
+// BenchmarkCanonicalBytes exercises the single-pass fast path that skips
+// building a *DN altogether.
+func BenchmarkCanonicalBytes(b *testing.B) {
+	corpus := dnCorpus(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := CanonicalBytes(corpus[i%len(corpus)]); err != nil {
+			b.Fatalf("CanonicalBytes: %s", err)
+		}
+	}
+}
+
+// BenchmarkDNStringReusedBuffer measures appendTo into a buffer the caller
+// keeps across iterations, the zero-allocation path appendTo exists for.
+func BenchmarkDNStringReusedBuffer(b *testing.B) {
+	corpus := dnCorpus(10000)
+	dns := make([]*DN, len(corpus))
+	for i, s := range corpus {
+		dn, err := New(s)
+		if err != nil {
+			b.Fatalf("New: %s", err)
+		}
+		dns[i] = dn
+	}
+	buf := make([]byte, 0, 256)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf = buf[:0]
+		buf = dns[i%len(dns)].appendTo(buf)
+	}
+}
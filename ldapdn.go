@@ -1,12 +1,17 @@
+// Package ldapdn implements RFC 4514 distinguished names: parsing,
+// canonicalization, comparison and the tree manipulations (Append, Strip,
+// Rename, Move, ...) that LDAP clients need when they move entries around.
 package ldapdn
 
 import (
 	enchex "encoding/hex"
 	"errors"
-	"sort"
+	"hash"
+	"hash/fnv"
 	"strings"
+	"unicode/utf8"
 
-	"gopkg.in/ldap.v2"
+	"github.com/vetinari/go-ldapdn/schema"
 )
 
 // ErrDNNotSubordinate is returned when the DN is not a subordinate of
@@ -17,6 +22,13 @@ var ErrDNNotSubordinate = errors.New("Not a subordinate")
 // not even
 var ErrInvalidNumberOfArgs = errors.New("Not an even number of arguments")
 
+// AttributeTypeAndValue is a single type=value pair of a RelativeDN, e.g.
+// the "cn=J. Smith" in "cn=J. Smith+ou=Sales".
+type AttributeTypeAndValue struct {
+	Type  string
+	Value string
+}
+
 // DN is a DN. When CaseFold is true, the RDN values are compared case
 // insensitive. With a true StringFold, dn.String() returns the string
 // lowercased.
@@ -26,9 +38,10 @@ type DN struct {
 	StringFold bool
 }
 
-// RelativeDN is part of a DN
+// RelativeDN is part of a DN, it holds one or more AttributeTypeAndValue
+// (more than one when the RDN is multi-valued, e.g. "ou=Sales+cn=J. Smith").
 type RelativeDN struct {
-	*ldap.RelativeDN
+	Attributes []*AttributeTypeAndValue
 }
 
 // CanonicalDN returns the canonical DN form of a DN, i.e.:
@@ -50,17 +63,10 @@ func CanonicalDN(dn string, fold ...bool) (string, error) {
 // argument sets CaseFold and StringFold to the given value -
 // only the first boolean is used.
 func New(dn string, fold ...bool) (*DN, error) {
-	ldn, err := ldap.ParseDN(dn)
+	rdns, err := parseDN(dn)
 	if err != nil {
 		return nil, err
 	}
-	var rdns []*RelativeDN
-	for _, r := range ldn.RDNs {
-		for _, av := range r.Attributes {
-			av.Type = strings.TrimSpace(av.Type)
-		}
-		rdns = append(rdns, &RelativeDN{r})
-	}
 	if len(fold) > 0 {
 		return &DN{RDNs: rdns, CaseFold: fold[0], StringFold: fold[0]}, nil
 	}
@@ -69,30 +75,63 @@ func New(dn string, fold ...bool) (*DN, error) {
 
 // String returns the stringified version of a *DN, the RDN values are escaped
 func (dn *DN) String() string {
-	var rdns []string
-	for _, r := range dn.RDNs {
-		rdns = append(rdns, r.String())
+	return string(dn.appendTo(nil))
+}
+
+// appendTo appends the stringified DN to buf and returns the extended
+// slice, in the style of strconv.AppendInt - the caller can reuse a buffer
+// across calls to avoid the per-call allocation String() makes.
+func (dn *DN) appendTo(buf []byte) []byte {
+	for i, r := range dn.RDNs {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = r.appendTo(buf, dn.StringFold)
 	}
-	if dn.StringFold {
-		return strings.ToLower(strings.Join(rdns, ","))
+	return buf
+}
+
+// CanonicalBytes parses dn and appends its canonical form directly to a
+// byte slice, without building a *DN: it is the fast path for callers that
+// only need the canonicalized bytes (e.g. to hash or compare) and would
+// otherwise throw away the *DN right after calling String().
+func CanonicalBytes(dn string) ([]byte, error) {
+	rdns, err := parseDN(dn)
+	if err != nil {
+		return nil, err
 	}
-	return strings.Join(rdns, ",")
+	var buf []byte
+	for i, r := range rdns {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = r.appendTo(buf, false)
+	}
+	return buf, nil
+}
+
+func escapeValue(value string) string {
+	return string(appendEscapedValue(nil, value))
 }
 
-func escapeValue(value string) (escaped string) {
+// appendEscapedValue appends value to buf with RFC 4514 special characters
+// backslash-escaped and control characters hex-escaped, without the
+// per-character string concatenation escapeValue used to do.
+func appendEscapedValue(buf []byte, value string) []byte {
 	for _, r := range value {
 		switch r {
 		case ',', '+', '"', '\\', '<', '>', ';', '#', '=':
-			escaped += "\\" + string(r)
+			buf = append(buf, '\\', byte(r))
 		default:
 			if uint(r) < 32 {
-				escaped += "\\" + enchex.EncodeToString([]byte(string(r)))
+				buf = append(buf, '\\')
+				buf = append(buf, enchex.EncodeToString([]byte(string(r)))...)
 			} else {
-				escaped += string(r)
+				buf = utf8.AppendRune(buf, r)
 			}
 		}
 	}
-	return
+	return buf
 }
 
 // Pretty returns a "pretty" version of a DN:
@@ -144,33 +183,52 @@ func NewRDN(rdn ...string) (*RelativeDN, error) {
 	if (len(rdn) % 2) != 0 {
 		return nil, ErrInvalidNumberOfArgs
 	}
-	lrdn := &ldap.RelativeDN{}
+	r := &RelativeDN{}
 	for {
 		if len(rdn) == 0 {
 			break
 		}
 		var attr, val string
 		attr, val, rdn = rdn[0], rdn[1], rdn[2:]
-		lrdn.Attributes = append(lrdn.Attributes, &ldap.AttributeTypeAndValue{Type: attr, Value: val})
+		r.Attributes = append(r.Attributes, &AttributeTypeAndValue{Type: attr, Value: val})
 	}
-	return &RelativeDN{lrdn}, nil
+	return r, nil
 }
 
-// Equal checks if all types and values of both RDNs are equal
+// Equal checks if all types and values of both RDNs are equal. Attribute
+// types known to the schema registry (see package schema) are compared
+// with their registered matching rule, e.g. "cn" is always compared
+// case-insensitively. fold is only consulted as a fallback for attribute
+// types the registry doesn't know, see Normalize.
 func (r *RelativeDN) Equal(o *RelativeDN, fold bool) bool {
 	if len(r.Attributes) != len(o.Attributes) {
 		return false
 	}
 	for i, av := range r.Attributes {
-		if strings.ToLower(av.Type) != strings.ToLower(o.Attributes[i].Type) {
+		ov := o.Attributes[i]
+		if strings.ToLower(av.Type) != strings.ToLower(ov.Type) {
 			return false
 		}
+		if equal, matched := schema.Default.Match(av.Type, av.Value, ov.Value); matched {
+			if !equal {
+				return false
+			}
+			continue
+		}
 		if fold {
-			if !strings.EqualFold(av.Value, o.Attributes[i].Value) {
+			nv, errV := normalizeValue(av.Value)
+			no, errO := normalizeValue(ov.Value)
+			if errV == nil && errO == nil {
+				if nv != no {
+					return false
+				}
+				continue
+			}
+			if !strings.EqualFold(av.Value, ov.Value) {
 				return false
 			}
 		} else {
-			if av.Value != o.Attributes[i].Value {
+			if av.Value != ov.Value {
 				return false
 			}
 		}
@@ -178,6 +236,18 @@ func (r *RelativeDN) Equal(o *RelativeDN, fold bool) bool {
 	return true
 }
 
+// Append builds a *DN that has the receiving RDN as its first RDN,
+// followed by the RDNs of other, e.g.
+//
+//  dn, err := ldapdn.New("cn=group,ou=some,ou=apps,dc=example,dc=org")
+//  dn.FirstRDN().Append(dn.Parent()).Equal(dn) -> true
+func (r *RelativeDN) Append(other *DN) *DN {
+	dn := &DN{RDNs: make([]*RelativeDN, 0, len(other.RDNs)+1)}
+	dn.RDNs = append(dn.RDNs, r)
+	dn.RDNs = append(dn.RDNs, other.RDNs...)
+	return dn
+}
+
 // IsSubordinate returns true if the "other" DN is a parent of "dn"
 func (dn *DN) IsSubordinate(other *DN) bool {
 	if other == nil {
@@ -265,11 +335,11 @@ func (dn *DN) Parent() *DN {
 
 // Clone returns a copy of the DN
 func (dn *DN) Clone() *DN {
-	c := &DN{}
+	c := &DN{CaseFold: dn.CaseFold, StringFold: dn.StringFold}
 	for _, r := range dn.RDNs {
 		rc, _ := NewRDN()
 		for _, tv := range r.Attributes {
-			rc.Attributes = append(rc.Attributes, &ldap.AttributeTypeAndValue{Type: tv.Type, Value: tv.Value})
+			rc.Attributes = append(rc.Attributes, &AttributeTypeAndValue{Type: tv.Type, Value: tv.Value})
 		}
 		c.RDNs = append(c.RDNs, rc)
 	}
@@ -277,18 +347,91 @@ func (dn *DN) Clone() *DN {
 }
 
 // Reverse reverses a DN, e.g. uid=user,ou=people,dc=example,dc=org becomes dc=org,dc=example,ou=people,uid=user
-func (d *DN) Reverse() *DN {
-	l := len(d.RDNs) - 1
-	dn := &DN{CaseFold: d.CaseFold, StringFold: d.StringFold, RDNs: make([]*RelativeDN, l+1)}
+func (dn *DN) Reverse() *DN {
+	l := len(dn.RDNs) - 1
+	r := &DN{CaseFold: dn.CaseFold, StringFold: dn.StringFold, RDNs: make([]*RelativeDN, l+1)}
 	for i := 0; i <= l; i++ {
-		dn.RDNs[i] = d.RDNs[l-i]
+		r.RDNs[i] = dn.RDNs[l-i]
+	}
+	return r
+}
+
+// Hash returns an FNV-1a hash of the DN's reversed RDN sequence (so
+// "uid=x,dc=example,dc=org" and "dc=org,dc=example,uid=x"'s reversal hash
+// the same), lowercasing each attribute's value where Equal would treat
+// case as insignificant: per the schema registry's matching rule when the
+// attribute type is registered (see foldForHash), or dn.CaseFold
+// otherwise. So two DNs that differ only in the case of such values hash
+// the same, and "x-custom=Foo,..." vs "x-custom=foo,..." (both
+// CaseFold=false) hash differently, as Equal reports them distinct.
+// Only case is folded: values that Equal matches for other reasons
+// (differing runs of whitespace, telephone-number punctuation, DN-valued
+// attributes written differently) hash differently.
+// Tree uses Hash as the identity of a DN and does no Equal check of its
+// own, so DNs whose hashes collide are treated by Tree as the same DN.
+func (dn *DN) Hash() uint64 {
+	h := fnv.New64a()
+	rev := dn.Reverse()
+	for i, r := range rev.RDNs {
+		if i > 0 {
+			h.Write([]byte{','})
+		}
+		r.writeHash(h, dn.CaseFold)
+	}
+	return h.Sum64()
+}
+
+// foldForHash reports whether attrType's value should be lowercased when
+// computing Hash, mirroring the precedence Equal uses: a schema-registered
+// matching rule decides first (e.g. "cn" is always folded, "serialNumber"
+// never is, see isCaseExact), and caseFold is only consulted as a fallback
+// for attribute types the registry doesn't know. Every registered rule
+// other than caseExactMatch, octetStringMatch and integerMatch, custom
+// rules included, is treated as folding.
+func foldForHash(attrType string, caseFold bool) bool {
+	name, ok := schema.Default.RuleName(attrType)
+	if !ok {
+		return caseFold
+	}
+	switch name {
+	case "caseExactMatch", "octetStringMatch", "integerMatch":
+		return false
+	default:
+		return true
+	}
+}
+
+// writeHash writes r's hash contribution to h, in the same attribute-type
+// sort order appendTo uses (and via the same non-mutating index sort).
+func (r *RelativeDN) writeHash(h hash.Hash, caseFold bool) {
+	var small [4]int
+	idx := small[:0]
+	for i := range r.Attributes {
+		idx = append(idx, i)
+	}
+	for i := 1; i < len(idx); i++ {
+		for j := i; j > 0 && r.Attributes[idx[j-1]].Type > r.Attributes[idx[j]].Type; j-- {
+			idx[j-1], idx[j] = idx[j], idx[j-1]
+		}
+	}
+	for pos, i := range idx {
+		if pos > 0 {
+			h.Write([]byte{'+'})
+		}
+		a := r.Attributes[i]
+		h.Write([]byte(strings.ToLower(a.Type)))
+		h.Write([]byte{'='})
+		val := a.Value
+		if foldForHash(a.Type, caseFold) {
+			val = strings.ToLower(val)
+		}
+		h.Write([]byte(val))
 	}
-	return dn
 }
 
 // DNS is used for sorting DNs:
 // (sometimes golint is annoyingly wrong, should be DNs...)
-//   all := []*ldap.DN{dn1, dn2, dn3, dn4}
+//   all := []*ldapdn.DN{dn1, dn2, dn3, dn4}
 //   sort.Sort(DNS(all))
 //   for _, dn := range all {
 //      println(dn.String())
@@ -317,7 +460,7 @@ func (d DNS) Less(i, j int) bool {
 	return d[i].Reverse().String() > d[j].Reverse().String()
 }
 
-type ava []*ldap.AttributeTypeAndValue
+type ava []*AttributeTypeAndValue
 
 func (a ava) Len() int {
 	return len(a)
@@ -333,11 +476,54 @@ func (a ava) Less(i, j int) bool {
 
 // String returns the stringified version of an RDN
 func (r *RelativeDN) String() string {
-	var parts []string
-	attrs := r.Attributes
-	sort.Sort(ava(attrs))
-	for _, a := range r.Attributes {
-		parts = append(parts, strings.ToLower(a.Type)+"="+escapeValue(a.Value))
+	return string(r.appendTo(nil, false))
+}
+
+// appendTo appends the stringified RDN to buf, in attribute-type sort
+// order. The sort is done over a small index array rather than
+// r.Attributes itself, so - unlike the sort.Sort(ava(r.Attributes)) this
+// replaced - it never mutates the caller's RelativeDN. For the common case
+// of four or fewer attributes, the index array doesn't escape to the heap.
+func (r *RelativeDN) appendTo(buf []byte, fold bool) []byte {
+	var small [4]int
+	idx := small[:0]
+	for i := range r.Attributes {
+		idx = append(idx, i)
+	}
+	// Insertion sort: RDNs are almost always single- or few-valued, so
+	// this is both simpler and allocates less than sort.Sort/sort.Slice.
+	for i := 1; i < len(idx); i++ {
+		for j := i; j > 0 && r.Attributes[idx[j-1]].Type > r.Attributes[idx[j]].Type; j-- {
+			idx[j-1], idx[j] = idx[j], idx[j-1]
+		}
+	}
+	for pos, i := range idx {
+		if pos > 0 {
+			buf = append(buf, '+')
+		}
+		a := r.Attributes[i]
+		buf = append(buf, strings.ToLower(a.Type)...)
+		buf = append(buf, '=')
+		val := a.Value
+		if fold && !isCaseExact(a.Type) {
+			val = strings.ToLower(val)
+		}
+		buf = appendEscapedValue(buf, val)
+	}
+	return buf
+}
+
+// isCaseExact reports whether attrType's registered matching rule compares
+// values exactly, so StringFold should leave its value's case alone.
+func isCaseExact(attrType string) bool {
+	name, ok := schema.Default.RuleName(attrType)
+	if !ok {
+		return false
+	}
+	switch name {
+	case "caseExactMatch", "octetStringMatch", "integerMatch":
+		return true
+	default:
+		return false
 	}
-	return strings.Join(parts, "+")
 }
@@ -45,7 +45,7 @@ func TestNewRDN(t *testing.T) {
 
 func TestDNInvalidString(t *testing.T) {
 	dn, err := New("uid=foo,bar,dc=example,dc=org")
-	if err != nil {
+	if err == nil {
 		t.Errorf("did not fail to parse invalid DN %s", dn)
 	}
 }
@@ -110,17 +110,36 @@ func TestDNMove(t *testing.T) {
 }
 
 func TestDNEqual(t *testing.T) {
+	// ou and dc are registered in the schema with caseIgnoreMatch, so they
+	// compare case-insensitively regardless of CaseFold.
 	dn1, _ := New("OU=people,DC=example,DC=org")
 	dn2, _ := New("ou=People,dc=Example,dc=ORG")
-	dn1.CaseFold = true
-	dn2.CaseFold = true
-	if !dn1.Equal(dn2) {
-		t.Errorf("both dns not equal")
-	}
 	dn1.CaseFold = false
 	dn2.CaseFold = false
-	if dn1.Equal(dn2) {
-		t.Errorf("both dns equal with ldap.RDNCompareFold = false")
+	if !dn1.Equal(dn2) {
+		t.Errorf("ou/dc are schema case-insensitive, both dns should be equal")
+	}
+
+	// serialNumber is registered with caseExactMatch, so it never folds,
+	// even with CaseFold set.
+	dn3, _ := New("serialNumber=ABC123", true)
+	dn4, _ := New("serialNumber=abc123", true)
+	if dn3.Equal(dn4) {
+		t.Errorf("serialNumber is schema case-exact, dns should not be equal")
+	}
+
+	// an attribute unknown to the schema registry falls back to CaseFold.
+	dn5, _ := New("x-custom=Foo")
+	dn6, _ := New("x-custom=foo")
+	dn5.CaseFold = true
+	dn6.CaseFold = true
+	if !dn5.Equal(dn6) {
+		t.Errorf("unknown attribute should fall back to CaseFold = true")
+	}
+	dn5.CaseFold = false
+	dn6.CaseFold = false
+	if dn5.Equal(dn6) {
+		t.Errorf("unknown attribute should fall back to CaseFold = false")
 	}
 }
 
@@ -224,3 +243,33 @@ func TestRDNAppend(t *testing.T) {
 		t.Errorf("append RDN failed...")
 	}
 }
+
+func TestDNHashMatchesEqual(t *testing.T) {
+	// cn is schema case-insensitive, so these hash equal regardless of
+	// CaseFold, just like Equal reports them equal.
+	dn1, _ := New("cn=J. Smith,dc=example,dc=org")
+	dn2, _ := New("cn=j. smith,dc=example,dc=org")
+	if !dn1.Equal(dn2) || dn1.Hash() != dn2.Hash() {
+		t.Errorf("cn is schema case-insensitive: Equal=%v, Hash1=%d, Hash2=%d", dn1.Equal(dn2), dn1.Hash(), dn2.Hash())
+	}
+
+	// an attribute unknown to the schema registry only folds under
+	// CaseFold, and Hash must agree with Equal here too.
+	dn3, _ := New("x-custom=Foo,dc=example,dc=com")
+	dn4, _ := New("x-custom=foo,dc=example,dc=com")
+	if dn3.Equal(dn4) || dn3.Hash() == dn4.Hash() {
+		t.Errorf("unknown attribute without CaseFold should differ: Equal=%v, Hash1=%d, Hash2=%d", dn3.Equal(dn4), dn3.Hash(), dn4.Hash())
+	}
+	dn3.CaseFold, dn4.CaseFold = true, true
+	if !dn3.Equal(dn4) || dn3.Hash() != dn4.Hash() {
+		t.Errorf("unknown attribute with CaseFold should match: Equal=%v, Hash1=%d, Hash2=%d", dn3.Equal(dn4), dn3.Hash(), dn4.Hash())
+	}
+
+	// serialNumber is schema case-exact, so it never folds, even with
+	// CaseFold set.
+	dn5, _ := New("serialNumber=ABC123", true)
+	dn6, _ := New("serialNumber=abc123", true)
+	if dn5.Equal(dn6) || dn5.Hash() == dn6.Hash() {
+		t.Errorf("serialNumber is schema case-exact: Equal=%v, Hash1=%d, Hash2=%d", dn5.Equal(dn6), dn5.Hash(), dn6.Hash())
+	}
+}
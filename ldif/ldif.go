@@ -0,0 +1,101 @@
+// Package ldif implements streaming RFC 2849 LDIF reading and writing on
+// top of package ldapdn, so DNs in an LDIF stream are always parsed and
+// printed the same way the rest of an ldapdn-based client would.
+package ldif
+
+import "github.com/vetinari/go-ldapdn"
+
+// Entry is a plain LDIF entry, i.e. a record with no "changetype:" line.
+type Entry struct {
+	DN         *ldapdn.DN
+	Attributes map[string][]string
+}
+
+// Op is the "changetype:" of a ChangeRecord.
+type Op int
+
+// The four LDIF change types (RFC 2849 section 4).
+const (
+	OpAdd Op = iota
+	OpDelete
+	OpModRDN
+	OpModify
+)
+
+func (o Op) String() string {
+	switch o {
+	case OpAdd:
+		return "add"
+	case OpDelete:
+		return "delete"
+	case OpModRDN:
+		return "modrdn"
+	case OpModify:
+		return "modify"
+	default:
+		return "unknown"
+	}
+}
+
+// ModOp is the add/delete/replace keyword of one block of a "changetype:
+// modify" record.
+type ModOp int
+
+// The three modify-block operations.
+const (
+	ModAdd ModOp = iota
+	ModDelete
+	ModReplace
+)
+
+func (o ModOp) String() string {
+	switch o {
+	case ModAdd:
+		return "add"
+	case ModDelete:
+		return "delete"
+	case ModReplace:
+		return "replace"
+	default:
+		return "unknown"
+	}
+}
+
+// Modification is one add/delete/replace block of a "changetype: modify"
+// record. It is also how a "changetype: add" record's attributes are
+// represented, each as a ModAdd Modification - a change-add record has no
+// per-attribute keyword of its own in RFC 2849, so this is the closest
+// fit to the single Mods field ChangeRecord uses for every change type.
+type Modification struct {
+	Op        ModOp
+	Attribute string
+	Values    []string
+}
+
+// ChangeRecord is an LDIF record with a "changetype:" line.
+type ChangeRecord struct {
+	DN   *ldapdn.DN
+	Op   Op
+	Mods []Modification // OpAdd and OpModify
+
+	NewRDN       *ldapdn.RelativeDN // OpModRDN
+	DeleteOldRDN bool               // OpModRDN
+	NewSuperior  *ldapdn.DN         // OpModRDN, optional
+}
+
+// Apply returns the DN that applying this ModRDN change record to cr.DN
+// would produce, by reusing DN.Rename and DN.Move on the NewRDN and
+// NewSuperior fields that Writer serializes as newrdn/newsuperior (Writer
+// itself writes those fields as they are and does not call Rename or
+// Move). Apply is a no-op for any other Op, returning cr.DN unchanged.
+func (cr *ChangeRecord) Apply() *ldapdn.DN {
+	if cr.Op != OpModRDN {
+		return cr.DN
+	}
+	dn := cr.DN.Clone()
+	dn.Rename(cr.NewRDN)
+	if cr.NewSuperior != nil {
+		dn.Move(cr.NewSuperior)
+	}
+	return dn
+}
@@ -0,0 +1,199 @@
+package ldif
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/vetinari/go-ldapdn"
+)
+
+func mustDN(t *testing.T, s string) *ldapdn.DN {
+	t.Helper()
+	dn, err := ldapdn.New(s)
+	if err != nil {
+		t.Fatalf("ldapdn.New(%q): %s", s, err)
+	}
+	return dn
+}
+
+func TestReadWriteEntryRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	in := &Entry{
+		DN: mustDN(t, "uid=jsmith,ou=people,dc=example,dc=org"),
+		Attributes: map[string][]string{
+			"cn":  {"J. Smith"},
+			"sn":  {"Smith"},
+			"uid": {"jsmith"},
+		},
+	}
+	if err := w.WriteEntry(in); err != nil {
+		t.Fatalf("WriteEntry: %s", err)
+	}
+
+	r := NewReader(&buf)
+	rec, err := r.Read()
+	if err != nil {
+		t.Fatalf("Read: %s", err)
+	}
+	out, ok := rec.(*Entry)
+	if !ok {
+		t.Fatalf("Read returned %T, want *Entry", rec)
+	}
+	if !out.DN.Equal(in.DN) {
+		t.Errorf("DN = %s, want %s", out.DN, in.DN)
+	}
+	for attr, vals := range in.Attributes {
+		if !stringsEqual(out.Attributes[attr], vals) {
+			t.Errorf("Attributes[%q] = %v, want %v", attr, out.Attributes[attr], vals)
+		}
+	}
+
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("second Read: got %v, want io.EOF", err)
+	}
+}
+
+func TestReadWriteBase64EncodedValues(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	in := &Entry{
+		DN: mustDN(t, "cn=Lučić,dc=example,dc=org"),
+		Attributes: map[string][]string{
+			"description": {" leading space", "contains\nnewline", "trailing space "},
+		},
+	}
+	if err := w.WriteEntry(in); err != nil {
+		t.Fatalf("WriteEntry: %s", err)
+	}
+
+	r := NewReader(&buf)
+	rec, err := r.Read()
+	if err != nil {
+		t.Fatalf("Read: %s", err)
+	}
+	out := rec.(*Entry)
+	if !out.DN.Equal(in.DN) {
+		t.Errorf("DN = %s, want %s", out.DN, in.DN)
+	}
+	if !stringsEqual(out.Attributes["description"], in.Attributes["description"]) {
+		t.Errorf("description = %q, want %q", out.Attributes["description"], in.Attributes["description"])
+	}
+}
+
+func TestWriteEntryBase64EncodesNonASCIIDN(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	in := &Entry{
+		DN:         mustDN(t, "cn=Lučić,dc=example,dc=org"),
+		Attributes: map[string][]string{"cn": {"Lučić"}},
+	}
+	if err := w.WriteEntry(in); err != nil {
+		t.Fatalf("WriteEntry: %s", err)
+	}
+
+	raw := buf.String()
+	if strings.Contains(raw, "dn: cn=Lučić,dc=example,dc=org\n") {
+		t.Errorf("non-ASCII dn was written as a plain line, want base64-encoded dn::\ngot:\n%s", raw)
+	}
+	wantDN := "dn:: " + base64.StdEncoding.EncodeToString([]byte(in.DN.String())) + "\n"
+	if !strings.Contains(raw, wantDN) {
+		t.Errorf("expected base64-encoded dn line %q, got:\n%s", wantDN, raw)
+	}
+	wantCN := "cn:: " + base64.StdEncoding.EncodeToString([]byte("Lučić")) + "\n"
+	if !strings.Contains(raw, wantCN) {
+		t.Errorf("expected base64-encoded cn line %q, got:\n%s", wantCN, raw)
+	}
+}
+
+func TestReadWriteChangeRecordAdd(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	in := &ChangeRecord{
+		DN: mustDN(t, "uid=jsmith,ou=people,dc=example,dc=org"),
+		Op: OpAdd,
+		Mods: []Modification{
+			{Op: ModAdd, Attribute: "cn", Values: []string{"J. Smith"}},
+			{Op: ModAdd, Attribute: "objectClass", Values: []string{"top", "person"}},
+		},
+	}
+	if err := w.WriteChangeRecord(in); err != nil {
+		t.Fatalf("WriteChangeRecord: %s", err)
+	}
+
+	r := NewReader(&buf)
+	rec, err := r.Read()
+	if err != nil {
+		t.Fatalf("Read: %s", err)
+	}
+	out := rec.(*ChangeRecord)
+	if out.Op != OpAdd || !out.DN.Equal(in.DN) {
+		t.Fatalf("got Op=%v DN=%s, want Op=%v DN=%s", out.Op, out.DN, in.Op, in.DN)
+	}
+	if len(out.Mods) != 2 || !stringsEqual(out.Mods[1].Values, []string{"top", "person"}) {
+		t.Errorf("Mods = %+v, want %+v", out.Mods, in.Mods)
+	}
+}
+
+func TestReadWriteChangeRecordModRDN(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	dn := mustDN(t, "uid=jsmith,ou=people,dc=example,dc=org")
+	newRDN, err := ldapdn.NewRDN("uid", "jqsmith")
+	if err != nil {
+		t.Fatalf("NewRDN: %s", err)
+	}
+	newSuperior := mustDN(t, "ou=alumni,dc=example,dc=org")
+	in := &ChangeRecord{
+		DN:           dn,
+		Op:           OpModRDN,
+		NewRDN:       newRDN,
+		DeleteOldRDN: true,
+		NewSuperior:  newSuperior,
+	}
+	if err := w.WriteChangeRecord(in); err != nil {
+		t.Fatalf("WriteChangeRecord: %s", err)
+	}
+
+	r := NewReader(&buf)
+	rec, err := r.Read()
+	if err != nil {
+		t.Fatalf("Read: %s", err)
+	}
+	out := rec.(*ChangeRecord)
+	if out.Op != OpModRDN || !out.DeleteOldRDN {
+		t.Fatalf("got Op=%v DeleteOldRDN=%v, want OpModRDN, true", out.Op, out.DeleteOldRDN)
+	}
+	if !out.NewRDN.Equal(in.NewRDN, false) {
+		t.Errorf("NewRDN = %s, want %s", out.NewRDN, in.NewRDN)
+	}
+	if !out.NewSuperior.Equal(in.NewSuperior) {
+		t.Errorf("NewSuperior = %s, want %s", out.NewSuperior, in.NewSuperior)
+	}
+
+	// Apply() reuses DN.Rename/DN.Move, matching what an LDAP client
+	// would compute locally after the server honors the modrdn.
+	got := out.Apply()
+	want := dn.Clone()
+	want.Rename(newRDN)
+	want.Move(newSuperior)
+	if !got.Equal(want) {
+		t.Errorf("Apply() = %s, want %s", got, want)
+	}
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,295 @@
+package ldif
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vetinari/go-ldapdn"
+)
+
+// Reader reads RFC 2849 LDIF records from an io.Reader, one at a time.
+type Reader struct {
+	sc          *bufio.Scanner
+	lookahead   *string
+	versionSeen bool
+}
+
+// NewReader returns a Reader reading LDIF records from r.
+func NewReader(r io.Reader) *Reader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return &Reader{sc: sc}
+}
+
+func (r *Reader) nextRaw() (string, bool) {
+	if r.lookahead != nil {
+		line := *r.lookahead
+		r.lookahead = nil
+		return line, true
+	}
+	if r.sc.Scan() {
+		return r.sc.Text(), true
+	}
+	return "", false
+}
+
+func (r *Reader) pushback(line string) {
+	r.lookahead = &line
+}
+
+// readRecord returns the unfolded, comment-stripped lines of the next
+// record, or io.EOF if there are none left.
+func (r *Reader) readRecord() ([]string, error) {
+	for {
+		line, ok := r.nextRaw()
+		if !ok {
+			return nil, io.EOF
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !r.versionSeen && strings.HasPrefix(line, "version:") {
+			r.versionSeen = true
+			continue
+		}
+		r.pushback(line)
+		break
+	}
+
+	var lines []string
+	for {
+		line, ok := r.nextRaw()
+		if !ok || line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, " ") {
+			if len(lines) == 0 {
+				return nil, fmt.Errorf("ldif: unexpected continuation line %q", line)
+			}
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil, io.EOF
+	}
+	return lines, nil
+}
+
+// parseAttrLine splits a logical "attr: value" or "attr:: base64value"
+// line into its attribute name and still-encoded value. An "attr:< url"
+// line is rejected with an error.
+func parseAttrLine(line string) (attr, value string, isBase64 bool, err error) {
+	idx := strings.IndexByte(line, ':')
+	if idx < 0 {
+		return "", "", false, fmt.Errorf("ldif: malformed attribute line %q", line)
+	}
+	attr = line[:idx]
+	rest := line[idx+1:]
+	switch {
+	case strings.HasPrefix(rest, ":"):
+		return attr, strings.TrimPrefix(rest[1:], " "), true, nil
+	case strings.HasPrefix(rest, "<"):
+		return "", "", false, fmt.Errorf("ldif: URL attribute values are not supported: %q", line)
+	default:
+		return attr, strings.TrimPrefix(rest, " "), false, nil
+	}
+}
+
+func decodeValue(encoded string, isBase64 bool) (string, error) {
+	if !isBase64 {
+		return encoded, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("ldif: invalid base64 value: %s", err)
+	}
+	return string(b), nil
+}
+
+// Read returns the next record as *Entry, or *ChangeRecord if it has a
+// "changetype:" line, and io.EOF once the stream is exhausted.
+func (r *Reader) Read() (interface{}, error) {
+	lines, err := r.readRecord()
+	if err != nil {
+		return nil, err
+	}
+
+	attr, encVal, isB64, err := parseAttrLine(lines[0])
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(attr, "dn") {
+		return nil, fmt.Errorf("ldif: record does not start with \"dn:\": %q", lines[0])
+	}
+	dnVal, err := decodeValue(encVal, isB64)
+	if err != nil {
+		return nil, err
+	}
+	dn, err := ldapdn.New(dnVal)
+	if err != nil {
+		return nil, fmt.Errorf("ldif: invalid dn %q: %s", dnVal, err)
+	}
+
+	rest := lines[1:]
+	if len(rest) > 0 {
+		a, v, b64, aerr := parseAttrLine(rest[0])
+		if aerr == nil && strings.EqualFold(a, "changetype") {
+			ct, verr := decodeValue(v, b64)
+			if verr != nil {
+				return nil, verr
+			}
+			return parseChangeRecord(dn, ct, rest[1:])
+		}
+	}
+	return parseEntry(dn, rest)
+}
+
+func parseEntry(dn *ldapdn.DN, lines []string) (*Entry, error) {
+	e := &Entry{DN: dn, Attributes: make(map[string][]string)}
+	for _, l := range lines {
+		a, v, b64, err := parseAttrLine(l)
+		if err != nil {
+			return nil, err
+		}
+		val, err := decodeValue(v, b64)
+		if err != nil {
+			return nil, err
+		}
+		e.Attributes[a] = append(e.Attributes[a], val)
+	}
+	return e, nil
+}
+
+func parseChangeRecord(dn *ldapdn.DN, changetype string, lines []string) (*ChangeRecord, error) {
+	switch strings.ToLower(changetype) {
+	case "add":
+		mods, err := parseAddAttrs(lines)
+		if err != nil {
+			return nil, err
+		}
+		return &ChangeRecord{DN: dn, Op: OpAdd, Mods: mods}, nil
+	case "delete":
+		return &ChangeRecord{DN: dn, Op: OpDelete}, nil
+	case "modrdn", "moddn":
+		return parseModRDN(dn, lines)
+	case "modify":
+		mods, err := parseModify(lines)
+		if err != nil {
+			return nil, err
+		}
+		return &ChangeRecord{DN: dn, Op: OpModify, Mods: mods}, nil
+	default:
+		return nil, fmt.Errorf("ldif: unknown changetype %q", changetype)
+	}
+}
+
+func parseAddAttrs(lines []string) ([]Modification, error) {
+	index := make(map[string]int)
+	var mods []Modification
+	for _, l := range lines {
+		a, v, b64, err := parseAttrLine(l)
+		if err != nil {
+			return nil, err
+		}
+		val, err := decodeValue(v, b64)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[a]; ok {
+			mods[i].Values = append(mods[i].Values, val)
+			continue
+		}
+		index[a] = len(mods)
+		mods = append(mods, Modification{Op: ModAdd, Attribute: a, Values: []string{val}})
+	}
+	return mods, nil
+}
+
+func parseModRDN(dn *ldapdn.DN, lines []string) (*ChangeRecord, error) {
+	cr := &ChangeRecord{DN: dn, Op: OpModRDN}
+	for _, l := range lines {
+		a, v, b64, err := parseAttrLine(l)
+		if err != nil {
+			return nil, err
+		}
+		val, err := decodeValue(v, b64)
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(a) {
+		case "newrdn":
+			rdn, err := ldapdn.New(val)
+			if err != nil {
+				return nil, fmt.Errorf("ldif: invalid newrdn %q: %s", val, err)
+			}
+			cr.NewRDN = rdn.FirstRDN()
+		case "deleteoldrdn":
+			cr.DeleteOldRDN = val == "1" || strings.EqualFold(val, "TRUE")
+		case "newsuperior":
+			sup, err := ldapdn.New(val)
+			if err != nil {
+				return nil, fmt.Errorf("ldif: invalid newsuperior %q: %s", val, err)
+			}
+			cr.NewSuperior = sup
+		default:
+			return nil, fmt.Errorf("ldif: unexpected attribute %q in modrdn record", a)
+		}
+	}
+	if cr.NewRDN == nil {
+		return nil, fmt.Errorf("ldif: modrdn record for %s is missing newrdn", dn)
+	}
+	return cr, nil
+}
+
+func parseModify(lines []string) ([]Modification, error) {
+	var mods []Modification
+	i := 0
+	for i < len(lines) {
+		a, v, b64, err := parseAttrLine(lines[i])
+		if err != nil {
+			return nil, err
+		}
+		var op ModOp
+		switch strings.ToLower(a) {
+		case "add":
+			op = ModAdd
+		case "delete":
+			op = ModDelete
+		case "replace":
+			op = ModReplace
+		default:
+			return nil, fmt.Errorf("ldif: expected add/delete/replace, got %q", lines[i])
+		}
+		attr, err := decodeValue(v, b64)
+		if err != nil {
+			return nil, err
+		}
+		m := Modification{Op: op, Attribute: attr}
+		i++
+		for i < len(lines) && lines[i] != "-" {
+			_, v2, b2, err := parseAttrLine(lines[i])
+			if err != nil {
+				return nil, err
+			}
+			val, err := decodeValue(v2, b2)
+			if err != nil {
+				return nil, err
+			}
+			m.Values = append(m.Values, val)
+			i++
+		}
+		if i < len(lines) && lines[i] == "-" {
+			i++
+		}
+		mods = append(mods, m)
+	}
+	return mods, nil
+}
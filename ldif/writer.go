@@ -0,0 +1,169 @@
+package ldif
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+const defaultWrapCol = 76
+
+// Writer writes RFC 2849 LDIF records to an io.Writer.
+type Writer struct {
+	w io.Writer
+	// WrapCol is the line-folding column. Zero means the RFC 2849
+	// suggestion of 76. Folding is byte-, not rune-, aware, so a
+	// multi-byte UTF-8 value could in principle be split across a fold;
+	// the value survives round-tripping regardless, since the fold is
+	// undone by joining lines before decoding.
+	WrapCol int
+}
+
+// NewWriter returns a Writer writing LDIF records to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+func needsBase64(v string) bool {
+	if v == "" {
+		return false
+	}
+	for i := 0; i < len(v); i++ {
+		if v[i] >= 0x80 {
+			return true
+		}
+		switch v[i] {
+		case 0, '\n', '\r':
+			return true
+		}
+	}
+	switch v[0] {
+	case ' ', ':', '<':
+		return true
+	}
+	return v[len(v)-1] == ' '
+}
+
+func (wr *Writer) writeAttr(attr, val string) error {
+	if needsBase64(val) {
+		return writeFolded(wr.w, attr+":: "+base64.StdEncoding.EncodeToString([]byte(val)), wr.WrapCol)
+	}
+	return writeFolded(wr.w, attr+": "+val, wr.WrapCol)
+}
+
+func writeFolded(w io.Writer, line string, wrapCol int) error {
+	if wrapCol <= 0 {
+		wrapCol = defaultWrapCol
+	}
+	first := true
+	for {
+		max := wrapCol
+		prefix := ""
+		if !first {
+			prefix = " "
+			max--
+		}
+		if max < 1 {
+			max = 1
+		}
+		chunk := line
+		more := false
+		if len(chunk) > max {
+			chunk = chunk[:max]
+			more = true
+		}
+		if _, err := io.WriteString(w, prefix+chunk+"\n"); err != nil {
+			return err
+		}
+		line = line[len(chunk):]
+		first = false
+		if !more {
+			return nil
+		}
+	}
+}
+
+// WriteEntry writes a plain LDIF entry, followed by a blank line.
+func (wr *Writer) WriteEntry(e *Entry) error {
+	if err := wr.writeAttr("dn", e.DN.String()); err != nil {
+		return err
+	}
+	for attr, vals := range e.Attributes {
+		for _, v := range vals {
+			if err := wr.writeAttr(attr, v); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := io.WriteString(wr.w, "\n")
+	return err
+}
+
+// WriteChangeRecord writes an LDIF change record, followed by a blank
+// line. A ModRDN record is written from NewRDN/DeleteOldRDN/NewSuperior
+// directly - see ChangeRecord.Apply for recovering the resulting DN via
+// DN.Rename/DN.Move.
+func (wr *Writer) WriteChangeRecord(cr *ChangeRecord) error {
+	if err := wr.writeAttr("dn", cr.DN.String()); err != nil {
+		return err
+	}
+	if err := wr.writeAttr("changetype", cr.Op.String()); err != nil {
+		return err
+	}
+	switch cr.Op {
+	case OpAdd:
+		for _, m := range cr.Mods {
+			for _, v := range m.Values {
+				if err := wr.writeAttr(m.Attribute, v); err != nil {
+					return err
+				}
+			}
+		}
+	case OpDelete:
+		// no body
+	case OpModRDN:
+		if cr.NewRDN == nil {
+			return fmt.Errorf("ldif: modrdn change record for %s has no NewRDN", cr.DN)
+		}
+		if err := wr.writeAttr("newrdn", cr.NewRDN.String()); err != nil {
+			return err
+		}
+		deleteOld := "0"
+		if cr.DeleteOldRDN {
+			deleteOld = "1"
+		}
+		if err := wr.writeAttr("deleteoldrdn", deleteOld); err != nil {
+			return err
+		}
+		if cr.NewSuperior != nil {
+			if err := wr.writeAttr("newsuperior", cr.NewSuperior.String()); err != nil {
+				return err
+			}
+		}
+	case OpModify:
+		for i, m := range cr.Mods {
+			if i > 0 {
+				if _, err := io.WriteString(wr.w, "-\n"); err != nil {
+					return err
+				}
+			}
+			if err := wr.writeAttr(m.Op.String(), m.Attribute); err != nil {
+				return err
+			}
+			for _, v := range m.Values {
+				if err := wr.writeAttr(m.Attribute, v); err != nil {
+					return err
+				}
+			}
+		}
+		if len(cr.Mods) > 0 {
+			if _, err := io.WriteString(wr.w, "-\n"); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("ldif: unknown changetype %v", cr.Op)
+	}
+	_, err := io.WriteString(wr.w, "\n")
+	return err
+}
@@ -0,0 +1,141 @@
+package ldapdn
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Normalize returns the RFC 4518 string-prepared form of the DN, i.e. the
+// form an LDAP server implementing distinguishedNameMatch/caseIgnoreMatch
+// would compare against: case-folded, with insignificant whitespace
+// collapsed and control characters rejected. When CaseFold is true,
+// DN.Equal applies the same per-value preparation to attribute types the
+// schema registry does not know; registered types are compared with their
+// registered matching rule instead.
+//
+// The NFKC compatibility-normalization step of RFC 4518 is not performed:
+// it requires Unicode decomposition tables (golang.org/x/text/unicode/norm)
+// that this module does not depend on, so values are passed through
+// unchanged at that step.
+func (dn *DN) Normalize() (string, error) {
+	parts := make([]string, 0, len(dn.RDNs))
+	for _, r := range dn.RDNs {
+		n, err := r.Normalize()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, n)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// Normalize returns the RFC 4518 string-prepared form of the RDN, see
+// DN.Normalize.
+func (r *RelativeDN) Normalize() (string, error) {
+	attrs := make([]*AttributeTypeAndValue, len(r.Attributes))
+	copy(attrs, r.Attributes)
+	sort.Sort(ava(attrs))
+
+	parts := make([]string, 0, len(attrs))
+	for _, a := range attrs {
+		nv, err := normalizeValue(a.Value)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, strings.ToLower(strings.TrimSpace(a.Type))+"="+nv)
+	}
+	return strings.Join(parts, "+"), nil
+}
+
+// normalizeValue runs the RFC 4518 string preparation pipeline
+// (Transcode -> Map -> Normalize -> Prohibit -> Check bidi -> Insignificant
+// Character Handling) over a single attribute value.
+func normalizeValue(v string) (string, error) {
+	// Transcode: input is already UTF-8, nothing to do.
+	mapped := mapChars(v)
+	folded := strings.Map(unicode.ToLower, mapped)
+	// Normalize (NFKC): see the doc comment on DN.Normalize.
+	if err := prohibitChars(folded); err != nil {
+		return "", err
+	}
+	if err := checkBidi(folded); err != nil {
+		return "", err
+	}
+	return collapseInsignificantSpaces(folded), nil
+}
+
+// mapChars implements the RFC 4518 Map step: format characters (e.g.
+// soft hyphen, zero-width space) are removed and every other Unicode
+// space separator is folded to U+0020.
+func mapChars(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Cf, r):
+			continue
+		case unicode.Is(unicode.Zs, r):
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// prohibitChars implements the RFC 4518 Prohibit step for the character
+// classes that stdlib's unicode package can classify without a full
+// Unicode properties database: controls, private-use and surrogates.
+func prohibitChars(s string) error {
+	for _, r := range s {
+		if unicode.IsControl(r) || unicode.Is(unicode.Co, r) || unicode.Is(unicode.Cs, r) {
+			return fmt.Errorf("ldapdn: prohibited character %U in value %q", r, s)
+		}
+	}
+	return nil
+}
+
+// checkBidi is the RFC 4518 Check Bidi step, which defers to the RFC 3454
+// section 6 bidirectional rule: a value containing any strong
+// right-to-left (RandALCat) character must not contain any strong
+// left-to-right (LCat) character, and must both start and end with a
+// RandALCat character.
+func checkBidi(s string) error {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return nil
+	}
+	var hasRandALCat, hasLCat bool
+	for _, r := range runes {
+		switch {
+		case isRandALCat(r):
+			hasRandALCat = true
+		case unicode.IsLetter(r):
+			hasLCat = true
+		}
+	}
+	if !hasRandALCat {
+		return nil
+	}
+	if hasLCat {
+		return errors.New("ldapdn: value mixes right-to-left and left-to-right characters")
+	}
+	if !isRandALCat(runes[0]) || !isRandALCat(runes[len(runes)-1]) {
+		return errors.New("ldapdn: right-to-left value must start and end with a right-to-left character")
+	}
+	return nil
+}
+
+func isRandALCat(r rune) bool {
+	return unicode.Is(unicode.Hebrew, r) || unicode.Is(unicode.Arabic, r)
+}
+
+// collapseInsignificantSpaces implements a simplified version of the RFC
+// 4518 Insignificant Character Handling step for caseIgnoreMatch-like
+// matching: leading and trailing whitespace is dropped, and every run of
+// internal whitespace is collapsed to a single space.
+func collapseInsignificantSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,51 @@
+package ldapdn
+
+import "testing"
+
+func TestNormalizeCollapsesWhitespaceAndCase(t *testing.T) {
+	dn, err := New("cn=  John   Q.  Public  ,dc=example,dc=org")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got, err := dn.Normalize()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := "cn=john q. public,dc=example,dc=org"
+	if got != want {
+		t.Errorf("Normalize() = %q, want %q", got, want)
+	}
+}
+
+func TestNormalizeProhibitsControlChars(t *testing.T) {
+	dn, err := New("cn=Bad\\07Value")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := dn.Normalize(); err == nil {
+		t.Errorf("expected Normalize() to reject a control character")
+	}
+}
+
+func TestNormalizeRejectsMixedBidi(t *testing.T) {
+	dn, err := New("cn=abcאב")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := dn.Normalize(); err == nil {
+		t.Errorf("expected Normalize() to reject mixed LTR/RTL text")
+	}
+}
+
+func TestEqualCaseFoldUsesNormalize(t *testing.T) {
+	// x-custom is unknown to the schema registry, so Equal's schema-match
+	// path doesn't apply here and CaseFold must route through
+	// normalizeValue for this assertion to mean anything (cn, by
+	// contrast, is schema-registered with caseIgnoreMatch and would
+	// equal regardless of CaseFold).
+	dn1, _ := New("x-custom=  John   Public ,dc=example,dc=org", true)
+	dn2, _ := New("x-custom=John Public,dc=example,dc=org", true)
+	if !dn1.Equal(dn2) {
+		t.Errorf("expected DNs to be equal under CaseFold normalization")
+	}
+}
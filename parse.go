@@ -0,0 +1,249 @@
+package ldapdn
+
+import (
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// parseDN is a hand-written RFC 4514 ("String Representation of
+// Distinguished Names") tokenizer, with the RFC 2253 double-quoting still
+// accepted for backward compatibility. It replaces the previous
+// gopkg.in/ldap.v2-backed parser.
+func parseDN(dn string) ([]*RelativeDN, error) {
+	if dn == "" {
+		return nil, nil
+	}
+	p := &dnParser{s: dn}
+	var rdns []*RelativeDN
+	for {
+		rdn, err := p.parseRDN()
+		if err != nil {
+			return nil, err
+		}
+		rdns = append(rdns, rdn)
+		p.skipSpace()
+		if p.eof() {
+			break
+		}
+		switch p.peek() {
+		case ',', ';':
+			p.pos++
+		default:
+			return nil, fmt.Errorf("ldapdn: invalid DN %q: unexpected character %q at offset %d", dn, p.s[p.pos], p.pos)
+		}
+	}
+	return rdns, nil
+}
+
+type dnParser struct {
+	s   string
+	pos int
+}
+
+func (p *dnParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *dnParser) peek() byte {
+	return p.s[p.pos]
+}
+
+func (p *dnParser) skipSpace() {
+	for !p.eof() && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *dnParser) parseRDN() (*RelativeDN, error) {
+	r := &RelativeDN{}
+	for {
+		p.skipSpace()
+		typ, err := p.parseAttributeType()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.eof() || p.peek() != '=' {
+			return nil, fmt.Errorf("ldapdn: invalid DN %q: expected '=' after attribute type %q", p.s, typ)
+		}
+		p.pos++
+		p.skipSpace()
+		val, err := p.parseAttributeValue()
+		if err != nil {
+			return nil, err
+		}
+		r.Attributes = append(r.Attributes, &AttributeTypeAndValue{Type: typ, Value: val})
+		p.skipSpace()
+		if !p.eof() && p.peek() == '+' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return r, nil
+}
+
+// parseAttributeType reads a descr (ALPHA (ALPHA / DIGIT / "-")*) or a
+// numericoid, stripping the legacy "oid." prefix some directories still
+// emit in front of a numericoid.
+func (p *dnParser) parseAttributeType() (string, error) {
+	start := p.pos
+	if strings.HasPrefix(strings.ToLower(p.s[p.pos:]), "oid.") {
+		p.pos += len("oid.")
+		start = p.pos
+	}
+	if p.eof() {
+		return "", fmt.Errorf("ldapdn: invalid DN %q: missing attribute type at offset %d", p.s, start)
+	}
+	if isDigit(p.s[p.pos]) {
+		for !p.eof() && (isDigit(p.s[p.pos]) || p.s[p.pos] == '.') {
+			p.pos++
+		}
+	} else {
+		for !p.eof() && (isAlphaNum(p.s[p.pos]) || p.s[p.pos] == '-') {
+			p.pos++
+		}
+	}
+	if p.pos == start {
+		return "", fmt.Errorf("ldapdn: invalid DN %q: missing attribute type at offset %d", p.s, start)
+	}
+	return p.s[start:p.pos], nil
+}
+
+// parseAttributeValue dispatches on the three attributeValue productions:
+// hexstring ("#..."), the RFC 2253 quoted string ('"..."') and the plain,
+// backslash-escaped string.
+func (p *dnParser) parseAttributeValue() (string, error) {
+	if p.eof() || p.peek() == ',' || p.peek() == '+' || p.peek() == ';' {
+		return "", nil
+	}
+	switch p.peek() {
+	case '#':
+		return p.parseHexValue()
+	case '"':
+		return p.parseQuotedValue()
+	default:
+		return p.parsePlainValue()
+	}
+}
+
+// parseHexValue decodes a "#"-prefixed hex-encoded BER value. RFC 4514
+// leaves interpretation of the BER content to the caller; we run it
+// through encoding/asn1 and take the tag's content bytes, falling back to
+// the raw decoded bytes if the value isn't well-formed BER/DER.
+func (p *dnParser) parseHexValue() (string, error) {
+	start := p.pos
+	p.pos++ // '#'
+	hexStart := p.pos
+	for !p.eof() && isHexDigit(p.s[p.pos]) {
+		p.pos++
+	}
+	hexStr := p.s[hexStart:p.pos]
+	if len(hexStr) == 0 || len(hexStr)%2 != 0 {
+		return "", fmt.Errorf("ldapdn: invalid DN %q: malformed hex value at offset %d", p.s, start)
+	}
+	data, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return "", fmt.Errorf("ldapdn: invalid DN %q: %s", p.s, err)
+	}
+	var raw asn1.RawValue
+	if _, err := asn1.Unmarshal(data, &raw); err == nil {
+		return string(raw.Bytes), nil
+	}
+	return string(data), nil
+}
+
+// parseQuotedValue reads the RFC 2253 double-quoted value kept for
+// backward compatibility, e.g. `"Sue, Grabbit and Runn"`.
+func (p *dnParser) parseQuotedValue() (string, error) {
+	start := p.pos
+	p.pos++ // opening '"'
+	var buf []byte
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("ldapdn: invalid DN %q: unterminated quoted value starting at offset %d", p.s, start)
+		}
+		c := p.s[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return string(buf), nil
+		case '\\':
+			b, err := p.readEscape()
+			if err != nil {
+				return "", err
+			}
+			buf = append(buf, b...)
+		default:
+			buf = append(buf, c)
+			p.pos++
+		}
+	}
+}
+
+// parsePlainValue reads an unquoted attribute value, un-escaping "\"
+// pairs and trimming unescaped leading/trailing spaces (they are
+// insignificant per RFC 4514; escaped ones are kept).
+func (p *dnParser) parsePlainValue() (string, error) {
+	var buf []byte
+	significant := 0
+	for {
+		if p.eof() {
+			break
+		}
+		c := p.s[p.pos]
+		if c == ',' || c == '+' || c == ';' {
+			break
+		}
+		if c == '\\' {
+			b, err := p.readEscape()
+			if err != nil {
+				return "", err
+			}
+			buf = append(buf, b...)
+			significant = len(buf)
+			continue
+		}
+		buf = append(buf, c)
+		p.pos++
+		if c != ' ' {
+			significant = len(buf)
+		}
+	}
+	return string(buf[:significant]), nil
+}
+
+// readEscape consumes a "\" followed by either a hex pair (a raw byte) or
+// a single special character, and returns the decoded byte(s).
+func (p *dnParser) readEscape() ([]byte, error) {
+	start := p.pos
+	p.pos++ // '\'
+	if p.eof() {
+		return nil, fmt.Errorf("ldapdn: invalid DN %q: trailing escape at offset %d", p.s, start)
+	}
+	if p.pos+1 < len(p.s) && isHexDigit(p.s[p.pos]) && isHexDigit(p.s[p.pos+1]) {
+		b, err := hex.DecodeString(p.s[p.pos : p.pos+2])
+		if err != nil {
+			return nil, fmt.Errorf("ldapdn: invalid DN %q: %s", p.s, err)
+		}
+		p.pos += 2
+		return b, nil
+	}
+	c := p.s[p.pos]
+	p.pos++
+	return []byte{c}, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlphaNum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || isDigit(c)
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,56 @@
+package ldapdn
+
+import "testing"
+
+func TestParseDNCorpus(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string // expected dn.String(), "" if wantErr
+		wantErr bool
+	}{
+		{in: "", want: ""},
+		{in: "cn=J. Smith", want: "cn=J. Smith"},
+		{in: "  cn = J. Smith  ", want: "cn=J. Smith"},
+		{in: `cn=James \"Jim\" Smith\, III`, want: `cn=James \"Jim\" Smith\, III`},
+		{in: `cn="James \"Jim\" Smith, III"`, want: `cn=James \"Jim\" Smith\, III`},
+		{in: `cn=Before\0dAfter`, want: `cn=Before\0dAfter`},
+		{in: "ou=Sales+cn=J. Smith", want: "cn=J. Smith+ou=Sales"},
+		{in: "1.3.6.1.4.1.1466.0=#04024869", want: "1.3.6.1.4.1.1466.0=Hi"},
+		{in: "oid.2.5.4.3=Jim", want: "2.5.4.3=Jim"},
+		{in: `cn=Lu\C4\8Di\C4\87`, want: "cn=Lučić"},
+		{in: `cn=\ leading and trailing space\ `, want: "cn= leading and trailing space "},
+		{in: "uid=foo,bar,dc=example,dc=org", wantErr: true},
+		{in: "cn=incomplete\\", wantErr: true},
+		{in: `cn="unterminated`, wantErr: true},
+		{in: "=noattribute", wantErr: true},
+		{in: "cn=a,", wantErr: true},
+	}
+	for _, tt := range tests {
+		dn, err := New(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("New(%q): expected error, got none (parsed as %q)", tt.in, dn.String())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("New(%q): unexpected error: %s", tt.in, err)
+			continue
+		}
+		if got := dn.String(); got != tt.want {
+			t.Errorf("New(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseDNHexValueNonBER(t *testing.T) {
+	// "#" followed by hex that isn't valid BER/DER falls back to the raw
+	// decoded bytes instead of failing.
+	dn, err := New("cn=#ffff")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := dn.RDN(), "\xff\xff"; got != want {
+		t.Errorf("RDN() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,97 @@
+// Package schema maps LDAP attribute types to the matching rule an LDAP
+// server would use to compare their values (RFC 4517/4519/4530), so that
+// callers can compare attribute values correctly without hardcoding a
+// single case-sensitivity choice for every attribute.
+package schema
+
+import (
+	"strings"
+	"sync"
+)
+
+// MatchFunc reports whether two attribute values are equal under a
+// particular matching rule, e.g. caseIgnoreMatch.
+type MatchFunc func(a, b string) bool
+
+// AttributeType associates an attribute's name, OID and aliases with the
+// matching rule used to compare its values.
+type AttributeType struct {
+	Name    string
+	OID     string
+	Aliases []string
+	Rule    string
+}
+
+// Registry maps attribute types (by name or OID, case-insensitively) to a
+// matching rule. The zero value is not usable, use NewRegistry.
+type Registry struct {
+	mu    sync.RWMutex
+	rules map[string]MatchFunc
+	attrs map[string]*AttributeType
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		rules: make(map[string]MatchFunc),
+		attrs: make(map[string]*AttributeType),
+	}
+}
+
+// RegisterRule adds or replaces a named matching rule.
+func (r *Registry) RegisterRule(name string, fn MatchFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules[name] = fn
+}
+
+// RegisterAttribute registers an attribute type under its name, OID and
+// any aliases. Its Rule must name a rule previously passed to
+// RegisterRule (order doesn't matter, the rule is looked up at Match time).
+func (r *Registry) RegisterAttribute(at AttributeType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a := &at
+	r.attrs[normalizeKey(at.Name)] = a
+	if at.OID != "" {
+		r.attrs[normalizeKey(at.OID)] = a
+	}
+	for _, alias := range at.Aliases {
+		r.attrs[normalizeKey(alias)] = a
+	}
+}
+
+func normalizeKey(attrType string) string {
+	key := strings.ToLower(attrType)
+	return strings.TrimPrefix(key, "oid.")
+}
+
+// RuleName returns the matching rule name registered for attrType (by
+// name or OID), if any.
+func (r *Registry) RuleName(attrType string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	at, ok := r.attrs[normalizeKey(attrType)]
+	if !ok {
+		return "", false
+	}
+	return at.Rule, true
+}
+
+// Match compares a and b per attrType's registered matching rule. matched
+// is false when attrType, or the rule it names, isn't registered - callers
+// should fall back to their own default comparison in that case.
+func (r *Registry) Match(attrType, a, b string) (equal bool, matched bool) {
+	r.mu.RLock()
+	at, ok := r.attrs[normalizeKey(attrType)]
+	if !ok {
+		r.mu.RUnlock()
+		return false, false
+	}
+	fn, ok := r.rules[at.Rule]
+	r.mu.RUnlock()
+	if !ok {
+		return false, false
+	}
+	return fn(a, b), true
+}
@@ -0,0 +1,82 @@
+package schema
+
+import "strings"
+
+// Default is the package-level Registry, pre-populated with the RFC 4519
+// user schema attributes and the matching rules from RFC 4517/4530 needed
+// to compare them. Callers may register additional attributes and rules
+// on it, or build an independent Registry with NewRegistry.
+//
+// distinguishedNameMatch is registered here as caseIgnoreMatch on attribute
+// values (this package has no notion of a DN); package ldapdn overrides it
+// at init time with a rule that actually parses and compares DNs.
+var Default = NewRegistry()
+
+func init() {
+	Default.RegisterRule("caseIgnoreMatch", caseIgnoreMatch)
+	Default.RegisterRule("caseExactMatch", caseExactMatch)
+	Default.RegisterRule("numericStringMatch", numericStringMatch)
+	Default.RegisterRule("telephoneNumberMatch", telephoneNumberMatch)
+	Default.RegisterRule("distinguishedNameMatch", caseIgnoreMatch)
+	Default.RegisterRule("octetStringMatch", caseExactMatch)
+	Default.RegisterRule("integerMatch", caseExactMatch)
+	Default.RegisterRule("uuidMatch", caseIgnoreMatch)
+
+	for _, at := range rfc4519Attributes {
+		Default.RegisterAttribute(at)
+	}
+}
+
+var rfc4519Attributes = []AttributeType{
+	{Name: "cn", OID: "2.5.4.3", Aliases: []string{"commonName"}, Rule: "caseIgnoreMatch"},
+	{Name: "sn", OID: "2.5.4.4", Aliases: []string{"surname"}, Rule: "caseIgnoreMatch"},
+	{Name: "ou", OID: "2.5.4.11", Aliases: []string{"organizationalUnitName"}, Rule: "caseIgnoreMatch"},
+	{Name: "o", OID: "2.5.4.10", Aliases: []string{"organizationName"}, Rule: "caseIgnoreMatch"},
+	{Name: "dc", OID: "0.9.2342.19200300.100.1.25", Aliases: []string{"domainComponent"}, Rule: "caseIgnoreMatch"},
+	{Name: "l", OID: "2.5.4.7", Aliases: []string{"localityName"}, Rule: "caseIgnoreMatch"},
+	{Name: "st", OID: "2.5.4.8", Aliases: []string{"stateOrProvinceName"}, Rule: "caseIgnoreMatch"},
+	{Name: "street", OID: "2.5.4.9", Aliases: []string{"streetAddress"}, Rule: "caseIgnoreMatch"},
+	{Name: "c", OID: "2.5.4.6", Aliases: []string{"countryName"}, Rule: "caseIgnoreMatch"},
+	{Name: "uid", OID: "0.9.2342.19200300.100.1.1", Aliases: []string{"userid"}, Rule: "caseIgnoreMatch"},
+	{Name: "givenName", OID: "2.5.4.42", Rule: "caseIgnoreMatch"},
+	{Name: "serialNumber", OID: "2.5.4.5", Rule: "caseExactMatch"},
+	{Name: "telephoneNumber", OID: "2.5.4.20", Rule: "telephoneNumberMatch"},
+	{Name: "member", OID: "2.5.4.31", Rule: "distinguishedNameMatch"},
+	{Name: "owner", OID: "2.5.4.32", Rule: "distinguishedNameMatch"},
+	{Name: "roleOccupant", OID: "2.5.4.33", Rule: "distinguishedNameMatch"},
+	{Name: "seeAlso", OID: "2.5.4.34", Rule: "distinguishedNameMatch"},
+	{Name: "manager", OID: "0.9.2342.19200300.100.1.10", Rule: "distinguishedNameMatch"},
+}
+
+func caseIgnoreMatch(a, b string) bool {
+	return strings.EqualFold(collapseSpace(a), collapseSpace(b))
+}
+
+func caseExactMatch(a, b string) bool {
+	return collapseSpace(a) == collapseSpace(b)
+}
+
+func numericStringMatch(a, b string) bool {
+	return stripSpace(a) == stripSpace(b)
+}
+
+func telephoneNumberMatch(a, b string) bool {
+	return strings.EqualFold(stripPhonePunctuation(a), stripPhonePunctuation(b))
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func stripSpace(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+func stripPhonePunctuation(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, s)
+}
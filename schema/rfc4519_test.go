@@ -0,0 +1,49 @@
+package schema
+
+import "testing"
+
+func TestDefaultRegistryMatching(t *testing.T) {
+	tests := []struct {
+		attr  string
+		a, b  string
+		equal bool
+	}{
+		{attr: "cn", a: "J. Smith", b: "j. smith", equal: true},
+		{attr: "2.5.4.3", a: "J. Smith", b: "j. smith", equal: true}, // OID alias for cn
+		{attr: "commonName", a: "J. Smith", b: "j.  smith", equal: true},
+		{attr: "serialNumber", a: "ABC123", b: "abc123", equal: false},
+		{attr: "telephoneNumber", a: "+1 555-0100", b: "+15550100", equal: true},
+	}
+	for _, tt := range tests {
+		equal, matched := Default.Match(tt.attr, tt.a, tt.b)
+		if !matched {
+			t.Errorf("Match(%q, ...): expected a registered rule", tt.attr)
+			continue
+		}
+		if equal != tt.equal {
+			t.Errorf("Match(%q, %q, %q) = %v, want %v", tt.attr, tt.a, tt.b, equal, tt.equal)
+		}
+	}
+}
+
+func TestMatchUnknownAttribute(t *testing.T) {
+	if _, matched := Default.Match("x-custom-attr", "a", "b"); matched {
+		t.Errorf("expected no rule registered for an unknown attribute")
+	}
+}
+
+func TestRegisterAttributeAndRule(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterRule("exactMatch", func(a, b string) bool { return a == b })
+	r.RegisterAttribute(AttributeType{Name: "x-widget-id", OID: "1.2.3.4", Rule: "exactMatch"})
+
+	if _, matched := r.Match("x-widget-id", "a", "b"); !matched {
+		t.Errorf("expected custom attribute to be registered")
+	}
+	if equal, _ := r.Match("1.2.3.4", "foo", "foo"); !equal {
+		t.Errorf("expected OID lookup to match the attribute registered by name")
+	}
+	if name, ok := r.RuleName("X-Widget-ID"); !ok || name != "exactMatch" {
+		t.Errorf("RuleName() = %q, %v, want \"exactMatch\", true", name, ok)
+	}
+}
@@ -0,0 +1,20 @@
+package ldapdn
+
+import "github.com/vetinari/go-ldapdn/schema"
+
+// package schema has no notion of a DN, so it registers
+// distinguishedNameMatch as a plain caseIgnoreMatch. Replace it with a rule
+// that actually parses both values as DNs and compares them RDN-by-RDN,
+// for attributes like "member" or "manager".
+func init() {
+	schema.Default.RegisterRule("distinguishedNameMatch", distinguishedNameMatch)
+}
+
+func distinguishedNameMatch(a, b string) bool {
+	dnA, errA := New(a, true)
+	dnB, errB := New(b, true)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return dnA.Equal(dnB)
+}
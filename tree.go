@@ -0,0 +1,163 @@
+package ldapdn
+
+// Tree indexes a set of DNs by their parent/child relationship, so that
+// users who load thousands of DNs from an LDAP subtree (the use case the
+// DNS sort type's docstring describes) can walk or delete them in
+// deepest-first order in O(n) instead of sort.Sort(DNS(...))'s O(n log n).
+//
+// Invariants:
+//   - a DN is attached under the tree node of its Parent() if, and only
+//     if, that parent has already been inserted; otherwise it is attached
+//     under a synthetic root. Inserting the parent later re-homes any of
+//     its pending children automatically.
+//   - every inserted DN has at most one parent present in the Tree.
+//   - Hash collisions between distinct DNs are not handled: Tree assumes
+//     DN.Hash() uniquely identifies a DN, which holds for the FNV-1a hash
+//     it uses in practice but isn't cryptographically guaranteed.
+type Tree struct {
+	root    *treeNode
+	nodes   map[uint64]*treeNode
+	orphans map[uint64]*treeNode
+}
+
+type treeNode struct {
+	dn       *DN
+	parent   *treeNode
+	children map[uint64]*treeNode
+}
+
+// NewTree returns an empty Tree.
+func NewTree() *Tree {
+	return &Tree{
+		root:    &treeNode{children: make(map[uint64]*treeNode)},
+		nodes:   make(map[uint64]*treeNode),
+		orphans: make(map[uint64]*treeNode),
+	}
+}
+
+// Insert adds dn to the tree, attaching it under its parent's node if
+// already present, or under the synthetic root otherwise. Inserting the
+// same DN (by Hash) twice is a no-op.
+func (t *Tree) Insert(dn *DN) {
+	h := dn.Hash()
+	if _, exists := t.nodes[h]; exists {
+		return
+	}
+	n := &treeNode{dn: dn, children: make(map[uint64]*treeNode)}
+	t.nodes[h] = n
+	t.attach(n)
+
+	for oh, orphan := range t.orphans {
+		if orphan.dn.Parent().Hash() == h {
+			delete(t.orphans, oh)
+			delete(t.root.children, oh)
+			t.link(n, orphan)
+		}
+	}
+}
+
+func (t *Tree) attach(n *treeNode) {
+	if len(n.dn.RDNs) == 0 {
+		// the DN of the root of the tree itself has no parent
+		t.link(t.root, n)
+		return
+	}
+	if p, ok := t.nodes[n.dn.Parent().Hash()]; ok {
+		t.link(p, n)
+		return
+	}
+	t.link(t.root, n)
+	t.orphans[n.dn.Hash()] = n
+}
+
+func (t *Tree) link(parent, child *treeNode) {
+	parent.children[child.dn.Hash()] = child
+	child.parent = parent
+}
+
+// Remove removes dn from the tree, reports whether it was present. Any
+// children it had become orphans of the synthetic root, the same as a DN
+// whose parent was never inserted.
+func (t *Tree) Remove(dn *DN) bool {
+	h := dn.Hash()
+	n, ok := t.nodes[h]
+	if !ok {
+		return false
+	}
+	if n.parent != nil {
+		delete(n.parent.children, h)
+	}
+	delete(t.nodes, h)
+	delete(t.orphans, h)
+	for ch, child := range n.children {
+		t.link(t.root, child)
+		t.orphans[ch] = child
+	}
+	return true
+}
+
+// Walk calls fn once for every DN in the tree, deepest-first: the DNs
+// linked below a node are always visited before the node's own DN. An
+// orphan of the synthetic root is not ordered relative to its ancestors
+// that are present in the tree, so deleting in Walk order is safe against
+// a live LDAP tree only when every intermediate DN was inserted.
+// Walk stops and returns the first error fn returns.
+func (t *Tree) Walk(fn func(*DN) error) error {
+	return walkNode(t.root, fn)
+}
+
+func walkNode(n *treeNode, fn func(*DN) error) error {
+	for _, c := range n.children {
+		if err := walkNode(c, fn); err != nil {
+			return err
+		}
+	}
+	if n.dn != nil {
+		return fn(n.dn)
+	}
+	return nil
+}
+
+// DescendantsOf returns every DN linked below base's node in the tree, in
+// no particular order. A subordinate DN that is an orphan of the synthetic
+// root (because a DN between it and base is absent) is not included.
+// Finding base is an O(1) map lookup, so this runs in O(1) plus the size
+// of the result.
+func (t *Tree) DescendantsOf(base *DN) []*DN {
+	n, ok := t.nodes[base.Hash()]
+	if !ok {
+		return nil
+	}
+	var out []*DN
+	collectDescendants(n, &out)
+	return out
+}
+
+func collectDescendants(n *treeNode, out *[]*DN) {
+	for _, c := range n.children {
+		*out = append(*out, c.dn)
+		collectDescendants(c, out)
+	}
+}
+
+// Subtree returns a new Tree containing base and everything below it in
+// t. base itself becomes an orphan of the new Tree's synthetic root, i.e.
+// the new Tree's own root. If base isn't in t, Subtree returns an empty
+// Tree.
+func (t *Tree) Subtree(base *DN) *Tree {
+	st := NewTree()
+	n, ok := t.nodes[base.Hash()]
+	if !ok {
+		return st
+	}
+	st.Insert(n.dn)
+	var copyChildren func(*treeNode)
+	copyChildren = func(p *treeNode) {
+		for _, c := range p.children {
+			st.Insert(c.dn)
+			copyChildren(c)
+		}
+	}
+	copyChildren(n)
+	return st
+}
@@ -0,0 +1,144 @@
+package ldapdn
+
+import "testing"
+
+func mustDN(t *testing.T, s string) *DN {
+	t.Helper()
+	dn, err := New(s)
+	if err != nil {
+		t.Fatalf("New(%q): %s", s, err)
+	}
+	return dn
+}
+
+func TestTreeWalkDeepestFirst(t *testing.T) {
+	tree := NewTree()
+	dns := []string{
+		"dc=example,dc=org",
+		"ou=people,dc=example,dc=org",
+		"uid=alice,ou=people,dc=example,dc=org",
+		"uid=bob,ou=people,dc=example,dc=org",
+		"ou=roles,dc=example,dc=org",
+	}
+	for _, s := range dns {
+		tree.Insert(mustDN(t, s))
+	}
+
+	seen := map[string]bool{}
+	var order []string
+	err := tree.Walk(func(dn *DN) error {
+		order = append(order, dn.String())
+		seen[dn.String()] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk: %s", err)
+	}
+	if len(order) != len(dns) {
+		t.Fatalf("Walk visited %d DNs, want %d", len(order), len(dns))
+	}
+
+	pos := map[string]int{}
+	for i, s := range order {
+		pos[s] = i
+	}
+	if pos["uid=alice,ou=people,dc=example,dc=org"] >= pos["ou=people,dc=example,dc=org"] {
+		t.Errorf("child visited after its parent")
+	}
+	if pos["ou=people,dc=example,dc=org"] >= pos["dc=example,dc=org"] {
+		t.Errorf("child visited after its parent")
+	}
+}
+
+func TestTreeInsertOutOfOrder(t *testing.T) {
+	tree := NewTree()
+	// the child arrives before its parent
+	tree.Insert(mustDN(t, "uid=alice,ou=people,dc=example,dc=org"))
+	tree.Insert(mustDN(t, "ou=people,dc=example,dc=org"))
+
+	got := tree.DescendantsOf(mustDN(t, "ou=people,dc=example,dc=org"))
+	if len(got) != 1 || got[0].String() != "uid=alice,ou=people,dc=example,dc=org" {
+		t.Errorf("DescendantsOf did not re-home the out-of-order child: %v", got)
+	}
+}
+
+func TestTreeRemove(t *testing.T) {
+	tree := NewTree()
+	tree.Insert(mustDN(t, "dc=example,dc=org"))
+	tree.Insert(mustDN(t, "ou=people,dc=example,dc=org"))
+	tree.Insert(mustDN(t, "uid=alice,ou=people,dc=example,dc=org"))
+
+	if !tree.Remove(mustDN(t, "ou=people,dc=example,dc=org")) {
+		t.Fatalf("Remove reported the DN as absent")
+	}
+	if tree.Remove(mustDN(t, "ou=people,dc=example,dc=org")) {
+		t.Errorf("Remove should be false for an already-removed DN")
+	}
+	// alice is now an orphan of the root, but still in the tree
+	got := tree.DescendantsOf(mustDN(t, "dc=example,dc=org"))
+	found := false
+	for _, dn := range got {
+		if dn.String() == "uid=alice,ou=people,dc=example,dc=org" {
+			found = true
+		}
+	}
+	if found {
+		t.Errorf("removed node's child should not still be a descendant of dc=example,dc=org")
+	}
+}
+
+func TestTreeSubtree(t *testing.T) {
+	tree := NewTree()
+	dns := []string{
+		"dc=example,dc=org",
+		"ou=people,dc=example,dc=org",
+		"uid=alice,ou=people,dc=example,dc=org",
+		"ou=roles,dc=example,dc=org",
+	}
+	for _, s := range dns {
+		tree.Insert(mustDN(t, s))
+	}
+
+	sub := tree.Subtree(mustDN(t, "ou=people,dc=example,dc=org"))
+	var got []string
+	_ = sub.Walk(func(dn *DN) error {
+		got = append(got, dn.String())
+		return nil
+	})
+	if len(got) != 2 {
+		t.Errorf("Subtree() walked %d DNs, want 2: %v", len(got), got)
+	}
+}
+
+func TestTreeInsertDistinctCaseVariants(t *testing.T) {
+	// x-custom is unknown to the schema registry and CaseFold defaults to
+	// false, so these two DNs are !Equal - Hash must not fold them
+	// together, or Insert would silently drop the second one.
+	tree := NewTree()
+	tree.Insert(mustDN(t, "dc=example,dc=com"))
+	tree.Insert(mustDN(t, "x-custom=Foo,dc=example,dc=com"))
+	tree.Insert(mustDN(t, "x-custom=foo,dc=example,dc=com"))
+	got := tree.DescendantsOf(mustDN(t, "dc=example,dc=com"))
+	if len(got) != 2 {
+		t.Fatalf("DescendantsOf found %d DNs, want 2 (distinct case variants collided): %v", len(got), got)
+	}
+
+	// serialNumber is registered with caseExactMatch, so it never folds
+	// regardless of CaseFold - same requirement applies.
+	tree2 := NewTree()
+	tree2.Insert(mustDN(t, "dc=example,dc=com"))
+	tree2.Insert(mustDN(t, "serialNumber=ABC123,dc=example,dc=com"))
+	tree2.Insert(mustDN(t, "serialNumber=abc123,dc=example,dc=com"))
+	got2 := tree2.DescendantsOf(mustDN(t, "dc=example,dc=com"))
+	if len(got2) != 2 {
+		t.Fatalf("DescendantsOf found %d DNs, want 2 (serialNumber case variants collided): %v", len(got2), got2)
+	}
+}
+
+func TestTreeDescendantsOfUnknownBase(t *testing.T) {
+	tree := NewTree()
+	tree.Insert(mustDN(t, "dc=example,dc=org"))
+	if got := tree.DescendantsOf(mustDN(t, "dc=elsewhere,dc=org")); got != nil {
+		t.Errorf("DescendantsOf of an absent base should be nil, got %v", got)
+	}
+}
@@ -0,0 +1,180 @@
+package ldapdn
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Scope is the search scope component of an LDAP URL (RFC 4516).
+type Scope int
+
+// The three LDAP search scopes.
+const (
+	ScopeBaseObject Scope = iota
+	ScopeSingleLevel
+	ScopeWholeSubtree
+)
+
+func (s Scope) String() string {
+	switch s {
+	case ScopeSingleLevel:
+		return "one"
+	case ScopeWholeSubtree:
+		return "sub"
+	default:
+		return "base"
+	}
+}
+
+func parseScope(s string) (Scope, error) {
+	switch s {
+	case "", "base":
+		return ScopeBaseObject, nil
+	case "one":
+		return ScopeSingleLevel, nil
+	case "sub":
+		return ScopeWholeSubtree, nil
+	default:
+		return 0, fmt.Errorf("invalid scope %q", s)
+	}
+}
+
+// URL is an RFC 4516 LDAP URL:
+// ldap://host:port/dn[?attrs[?scope[?filter[?extensions]]]]
+type URL struct {
+	Scheme     string // "ldap" or "ldaps"
+	Host       string // host[:port]
+	DN         *DN
+	Attributes []string
+	Scope      Scope
+	Filter     string
+	Extensions []string
+}
+
+// URL builds the RFC 4516 URL for dn on host, e.g. for use in a referral
+// or a bookmark.
+func (dn *DN) URL(host string, scope Scope, attrs []string, filter string) *URL {
+	return &URL{Scheme: "ldap", Host: host, DN: dn, Scope: scope, Attributes: attrs, Filter: filter}
+}
+
+// ParseURL parses an RFC 4516 LDAP URL. The dn component is
+// percent-decoded and then parsed with New, so "%2C" decodes to a plain
+// comma that New treats as an RDN separator; a comma inside an RDN's value
+// has to arrive DN-escaped, as "\," or "\%2C".
+func ParseURL(s string) (*URL, error) {
+	schemeEnd := strings.Index(s, "://")
+	if schemeEnd < 0 {
+		return nil, fmt.Errorf("ldapdn: invalid LDAP URL %q: missing scheme", s)
+	}
+	scheme := s[:schemeEnd]
+	if scheme != "ldap" && scheme != "ldaps" {
+		return nil, fmt.Errorf("ldapdn: invalid LDAP URL %q: unsupported scheme %q", s, scheme)
+	}
+
+	rest := s[schemeEnd+3:]
+	host, path := rest, ""
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		host, path = rest[:i], rest[i+1:]
+	}
+	u := &URL{Scheme: scheme, Host: host, Scope: ScopeBaseObject}
+
+	segs := strings.SplitN(path, "?", 5)
+	dnStr, err := url.PathUnescape(segs[0])
+	if err != nil {
+		return nil, fmt.Errorf("ldapdn: invalid LDAP URL %q: %s", s, err)
+	}
+	dn, err := New(dnStr)
+	if err != nil {
+		return nil, fmt.Errorf("ldapdn: invalid LDAP URL %q: invalid dn: %s", s, err)
+	}
+	u.DN = dn
+
+	if len(segs) > 1 && segs[1] != "" {
+		attrs, err := url.PathUnescape(segs[1])
+		if err != nil {
+			return nil, fmt.Errorf("ldapdn: invalid LDAP URL %q: %s", s, err)
+		}
+		u.Attributes = strings.Split(attrs, ",")
+	}
+	if len(segs) > 2 {
+		sc, err := parseScope(segs[2])
+		if err != nil {
+			return nil, fmt.Errorf("ldapdn: invalid LDAP URL %q: %s", s, err)
+		}
+		u.Scope = sc
+	}
+	if len(segs) > 3 && segs[3] != "" {
+		filter, err := url.PathUnescape(segs[3])
+		if err != nil {
+			return nil, fmt.Errorf("ldapdn: invalid LDAP URL %q: %s", s, err)
+		}
+		u.Filter = filter
+	}
+	if len(segs) > 4 && segs[4] != "" {
+		ext, err := url.PathUnescape(segs[4])
+		if err != nil {
+			return nil, fmt.Errorf("ldapdn: invalid LDAP URL %q: %s", s, err)
+		}
+		u.Extensions = strings.Split(ext, ",")
+	}
+	return u, nil
+}
+
+// String formats u back into an RFC 4516 LDAP URL. In the dn component it
+// percent-encodes ",", "+", "?", "/", "#", control characters and
+// non-ASCII UTF-8 bytes; in the filter component the same except "," and
+// "+", which are left as they are. Trailing empty components are omitted.
+func (u *URL) String() string {
+	scheme := u.Scheme
+	if scheme == "" {
+		scheme = "ldap"
+	}
+
+	var b strings.Builder
+	b.WriteString(scheme)
+	b.WriteString("://")
+	b.WriteString(u.Host)
+	b.WriteByte('/')
+	if u.DN != nil {
+		b.WriteString(percentEncode(u.DN.String(), ",+?/#"))
+	}
+
+	hasAttrs := len(u.Attributes) > 0
+	hasScope := u.Scope != ScopeBaseObject
+	hasFilter := u.Filter != ""
+	hasExt := len(u.Extensions) > 0
+
+	if hasAttrs || hasScope || hasFilter || hasExt {
+		b.WriteByte('?')
+		b.WriteString(strings.Join(u.Attributes, ","))
+	}
+	if hasScope || hasFilter || hasExt {
+		b.WriteByte('?')
+		b.WriteString(u.Scope.String())
+	}
+	if hasFilter || hasExt {
+		b.WriteByte('?')
+		b.WriteString(percentEncode(u.Filter, "?/#"))
+	}
+	if hasExt {
+		b.WriteByte('?')
+		b.WriteString(strings.Join(u.Extensions, ","))
+	}
+	return b.String()
+}
+
+// percentEncode percent-encodes every byte of s that is in reserved, a
+// control character, or part of a non-ASCII (UTF-8 multi-byte) sequence.
+func percentEncode(s string, reserved string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c >= 0x80 || strings.IndexByte(reserved, c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
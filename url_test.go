@@ -0,0 +1,114 @@
+package ldapdn
+
+import "testing"
+
+func TestParseURLBasic(t *testing.T) {
+	u, err := ParseURL("ldap://ldap.example.org:389/dc=example,dc=org?cn,sn?sub?(uid=jsmith)")
+	if err != nil {
+		t.Fatalf("ParseURL: %s", err)
+	}
+	if u.Scheme != "ldap" || u.Host != "ldap.example.org:389" {
+		t.Errorf("Scheme/Host = %q/%q, want ldap/ldap.example.org:389", u.Scheme, u.Host)
+	}
+	want, _ := New("dc=example,dc=org")
+	if !u.DN.Equal(want) {
+		t.Errorf("DN = %s, want %s", u.DN, want)
+	}
+	if len(u.Attributes) != 2 || u.Attributes[0] != "cn" || u.Attributes[1] != "sn" {
+		t.Errorf("Attributes = %v, want [cn sn]", u.Attributes)
+	}
+	if u.Scope != ScopeWholeSubtree {
+		t.Errorf("Scope = %v, want ScopeWholeSubtree", u.Scope)
+	}
+	if u.Filter != "(uid=jsmith)" {
+		t.Errorf("Filter = %q, want (uid=jsmith)", u.Filter)
+	}
+}
+
+func TestParseURLDefaults(t *testing.T) {
+	u, err := ParseURL("ldap://ldap.example.org/dc=example,dc=org")
+	if err != nil {
+		t.Fatalf("ParseURL: %s", err)
+	}
+	if u.Scope != ScopeBaseObject {
+		t.Errorf("Scope = %v, want ScopeBaseObject", u.Scope)
+	}
+	if len(u.Attributes) != 0 || u.Filter != "" || len(u.Extensions) != 0 {
+		t.Errorf("expected empty Attributes/Filter/Extensions, got %+v", u)
+	}
+}
+
+func TestParseURLPercentEncodedDN(t *testing.T) {
+	u, err := ParseURL("ldap://ldap.example.org/cn=Lu%C4%8Di%C4%87,dc=example,dc=org")
+	if err != nil {
+		t.Fatalf("ParseURL: %s", err)
+	}
+	want, _ := New("cn=Lučić,dc=example,dc=org")
+	if !u.DN.Equal(want) {
+		t.Errorf("DN = %s, want %s", u.DN, want)
+	}
+}
+
+func TestParseURLInvalidScheme(t *testing.T) {
+	if _, err := ParseURL("http://ldap.example.org/dc=example,dc=org"); err == nil {
+		t.Error("expected error for non-ldap scheme")
+	}
+}
+
+func TestParseURLInvalidScope(t *testing.T) {
+	if _, err := ParseURL("ldap://ldap.example.org/dc=example,dc=org??bogus"); err == nil {
+		t.Error("expected error for invalid scope")
+	}
+}
+
+func TestURLStringRoundTrip(t *testing.T) {
+	dn, err := New("dc=example,dc=org")
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+	u := dn.URL("ldap.example.org", ScopeSingleLevel, []string{"cn", "sn"}, "(objectClass=*)")
+	s := u.String()
+	want := "ldap://ldap.example.org/dc=example%2Cdc=org?cn,sn?one?(objectClass=*)"
+	if s != want {
+		t.Errorf("String() = %q, want %q", s, want)
+	}
+
+	parsed, err := ParseURL(s)
+	if err != nil {
+		t.Fatalf("ParseURL(%q): %s", s, err)
+	}
+	if !parsed.DN.Equal(u.DN) || parsed.Scope != u.Scope || parsed.Filter != u.Filter {
+		t.Errorf("round trip mismatch: got %+v, want %+v", parsed, u)
+	}
+}
+
+func TestURLStringPercentEncodesDNAndFilter(t *testing.T) {
+	dn, err := New(`cn=Smith\, James,dc=example,dc=org`)
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+	u := dn.URL("ldap.example.org", ScopeBaseObject, nil, "")
+	s := u.String()
+	if want := `ldap://ldap.example.org/cn=Smith\%2C James%2Cdc=example%2Cdc=org`; s != want {
+		t.Errorf("String() = %q, want %q", s, want)
+	}
+
+	u2 := dn.URL("ldap.example.org", ScopeWholeSubtree, nil, "(cn=Lučić)")
+	s2 := u2.String()
+	parsed, err := ParseURL(s2)
+	if err != nil {
+		t.Fatalf("ParseURL(%q): %s", s2, err)
+	}
+	if parsed.Filter != "(cn=Lučić)" {
+		t.Errorf("Filter = %q, want (cn=Lučić)", parsed.Filter)
+	}
+}
+
+func TestScopeString(t *testing.T) {
+	cases := map[Scope]string{ScopeBaseObject: "base", ScopeSingleLevel: "one", ScopeWholeSubtree: "sub"}
+	for scope, want := range cases {
+		if got := scope.String(); got != want {
+			t.Errorf("Scope(%d).String() = %q, want %q", scope, got, want)
+		}
+	}
+}